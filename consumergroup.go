@@ -0,0 +1,117 @@
+package main
+
+import (
+    "runtime"
+    "sync"
+    "time"
+)
+
+// ConsumerGroupConfig are the batching knobs for a ConsumerGroup. Zero values
+// fall back to sensible defaults, applied by NewConsumerGroup.
+type ConsumerGroupConfig struct {
+    BatchSize     int           // Flush once a batch reaches this many messages
+    FlushInterval time.Duration // Flush a non-empty batch after this long, whichever comes first
+    Workers       int           // Worker goroutines draining the merge channel
+    ChanSize      int           // Merge channel buffer size
+}
+
+func (c ConsumerGroupConfig) withDefaults() ConsumerGroupConfig {
+    if c.BatchSize <= 0 {
+        c.BatchSize = 1024
+    }
+    if c.FlushInterval <= 0 {
+        c.FlushInterval = 5 * time.Second
+    }
+    if c.Workers <= 0 {
+        c.Workers = runtime.GOMAXPROCS(0)
+    }
+    if c.ChanSize <= 0 {
+        c.ChanSize = 1024
+    }
+    return c
+}
+
+// ConsumerGroup accumulates messages fed to it through a merge channel and
+// hands them to a user-supplied Do callback in batches, so downstream I/O
+// pays its cost once per batch instead of once per message. A batch flushes
+// once it reaches BatchSize, or after FlushInterval if it hasn't, whichever
+// comes first.
+type ConsumerGroup struct {
+    cfg       ConsumerGroupConfig
+    merge     chan []byte
+    batchPool sync.Pool
+    wg        sync.WaitGroup
+}
+
+// NewConsumerGroup builds a ConsumerGroup; call Run to start its workers.
+func NewConsumerGroup(cfg ConsumerGroupConfig) *ConsumerGroup {
+    cfg = cfg.withDefaults()
+    g := &ConsumerGroup{
+        cfg:   cfg,
+        merge: make(chan []byte, cfg.ChanSize),
+    }
+    g.batchPool.New = func() interface{} {
+        return make([][]byte, 0, cfg.BatchSize)
+    }
+    return g
+}
+
+// Feed queues a message onto the group's merge channel. It blocks if ChanSize
+// workers haven't drained it yet.
+func (g *ConsumerGroup) Feed(msg []byte) {
+    g.merge <- msg
+}
+
+// Close signals the workers that no more messages are coming; it flushes
+// whatever each worker is still holding and waits for them to exit.
+func (g *ConsumerGroup) Close() {
+    close(g.merge)
+    g.wg.Wait()
+}
+
+// Run starts cfg.Workers worker goroutines, each independently batching
+// messages off the merge channel and handing them to do.
+func (g *ConsumerGroup) Run(do func(msgs [][]byte)) {
+    g.wg.Add(g.cfg.Workers)
+    for w := 0; w < g.cfg.Workers; w++ {
+        go func() {
+            defer g.wg.Done()
+            g.worker(do)
+        }()
+    }
+}
+
+func (g *ConsumerGroup) worker(do func(msgs [][]byte)) {
+    ticker := time.NewTicker(g.cfg.FlushInterval)
+    defer ticker.Stop()
+
+    batch := g.batchPool.Get().([][]byte)[:0]
+
+    flush := func() {
+        if len(batch) == 0 {
+            return
+        }
+        do(batch)
+        for i := range batch {
+            batch[i] = nil // drop references before returning the slice to the pool
+        }
+        g.batchPool.Put(batch[:0])
+        batch = g.batchPool.Get().([][]byte)[:0]
+    }
+
+    for {
+        select {
+        case msg, ok := <-g.merge:
+            if !ok {
+                flush()
+                return
+            }
+            batch = append(batch, msg)
+            if len(batch) >= g.cfg.BatchSize {
+                flush()
+            }
+        case <-ticker.C:
+            flush()
+        }
+    }
+}
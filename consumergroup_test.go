@@ -0,0 +1,72 @@
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+// TestConsumerGroupSizeFlush checks a batch flushes as soon as it reaches
+// BatchSize, independent of FlushInterval (set far longer than the test can
+// run, so only the size trigger can fire).
+func TestConsumerGroupSizeFlush(t *testing.T) {
+    g := NewConsumerGroup(ConsumerGroupConfig{
+        BatchSize:     10,
+        FlushInterval: time.Hour,
+        Workers:       1,
+        ChanSize:      100,
+    })
+
+    flushes := make(chan int, 10)
+    g.Run(func(msgs [][]byte) {
+        flushes <- len(msgs)
+    })
+
+    for i := 0; i < 25; i++ {
+        g.Feed([]byte{byte(i)})
+    }
+    g.Close()
+    close(flushes)
+
+    var got []int
+    for n := range flushes {
+        got = append(got, n)
+    }
+    want := []int{10, 10, 5}
+    if len(got) != len(want) {
+        t.Fatalf("flush batch sizes = %v, want %v", got, want)
+    }
+    for i := range want {
+        if got[i] != want[i] {
+            t.Fatalf("flush batch sizes = %v, want %v", got, want)
+        }
+    }
+}
+
+// TestConsumerGroupTimeFlush checks a non-empty batch below BatchSize still
+// flushes once FlushInterval elapses.
+func TestConsumerGroupTimeFlush(t *testing.T) {
+    g := NewConsumerGroup(ConsumerGroupConfig{
+        BatchSize:     1000, // never reached
+        FlushInterval: 20 * time.Millisecond,
+        Workers:       1,
+        ChanSize:      100,
+    })
+
+    flushed := make(chan int, 1)
+    g.Run(func(msgs [][]byte) {
+        flushed <- len(msgs)
+    })
+
+    g.Feed([]byte("only one message"))
+
+    select {
+    case n := <-flushed:
+        if n != 1 {
+            t.Fatalf("flushed %v messages, want 1", n)
+        }
+    case <-time.After(200 * time.Millisecond):
+        t.Fatal("time-based flush never fired")
+    }
+
+    g.Close()
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+    "runtime"
+    "sync/atomic"
+    "time"
+)
+
+// DisruptorEntry is a single ring buffer slot. It carries the routed bus key
+// (computed the same way as EventBus.Key, kept as an optional routing layer)
+// and the fixed-size payload, plus the consumer this message is addressed to
+// so Consume can preserve the existing Producer[p]->Consumer[p] pairing on
+// top of a single shared buffer.
+type DisruptorEntry struct {
+    Key      EventBusDataKey
+    Consumer int
+    Msg      EventBusDataValueMessage
+}
+
+// Disruptor is a pre-allocated, power-of-two ring buffer shared by all
+// producers and consumers. It replaces EventBusPublishQueues and the
+// per-cell sync.RWMutex with a single lock-free claim/publish/consume
+// pipeline (LMAX-style): producers claim a slot by CAS-ing a shared producer
+// cursor, write the payload, then publish it by storing the claimed sequence
+// into published[slot]. Consumers each keep their own cursor and spin,
+// waiting for published[cursor&mask] == cursor, before reading the slot.
+type Disruptor struct {
+    mask            uint64
+    entries         []DisruptorEntry
+    published       []int64 // published[i]: sequence last published into slot i, or -1 if free
+    producerCursor  int64   // next sequence to claim (atomic)
+    consumerCursors []int64 // per-consumer read cursor, one behind next to read (atomic)
+    done            int32   // set once producers are finished publishing (atomic bool)
+}
+
+// NewDisruptor allocates a ring buffer sized to the next power of two >= size,
+// shared by numConsumers independent readers.
+func NewDisruptor(size int, numConsumers int) *Disruptor {
+    sz := 1
+    for sz < size {
+        sz <<= 1
+    }
+
+    d := &Disruptor{
+        mask:            uint64(sz - 1),
+        entries:         make([]DisruptorEntry, sz),
+        published:       make([]int64, sz),
+        consumerCursors: make([]int64, numConsumers),
+    }
+    for i := range d.published {
+        d.published[i] = -1
+    }
+    for i := range d.consumerCursors {
+        d.consumerCursors[i] = -1
+    }
+    return d
+}
+
+// minConsumerCursor returns the slowest consumer's cursor. Producers use it as
+// a barrier so a claim can never lap a consumer that hasn't freed its slot yet.
+func (d *Disruptor) minConsumerCursor() int64 {
+    min := atomic.LoadInt64(&d.consumerCursors[0])
+    for i := 1; i < len(d.consumerCursors); i++ {
+        if c := atomic.LoadInt64(&d.consumerCursors[i]); c < min {
+            min = c
+        }
+    }
+    return min
+}
+
+// Publish claims the next free slot with a CAS on the shared producer cursor
+// (multi-producer claim strategy), writes the payload into it, then makes it
+// visible to consumers by storing the claimed sequence into published[slot].
+func (d *Disruptor) Publish(entry DisruptorEntry) {
+    size := int64(len(d.entries))
+    var claimed int64
+    for {
+        cur := atomic.LoadInt64(&d.producerCursor)
+        for cur-d.minConsumerCursor() >= size { // barrier: ring is full, wait for consumers
+            runtime.Gosched()
+        }
+        if atomic.CompareAndSwapInt64(&d.producerCursor, cur, cur+1) {
+            claimed = cur
+            break
+        }
+    }
+
+    slot := uint64(claimed) & d.mask
+    d.entries[slot] = entry
+    atomic.StoreInt64(&d.published[slot], claimed)
+}
+
+// Close signals that no further messages will be published. Consumers drain
+// whatever remains on the ring, then Consume returns.
+func (d *Disruptor) Close() {
+    atomic.StoreInt32(&d.done, 1)
+}
+
+// Consume blocks the calling goroutine, invoking fn (in publish order) for
+// every entry addressed to consumer, until the ring is drained after Close.
+// While waiting for the next slot to be published it backs off from a
+// busy-spin to runtime.Gosched to a short sleep.
+func (d *Disruptor) Consume(consumer int, fn func(entry DisruptorEntry)) {
+    next := atomic.LoadInt64(&d.consumerCursors[consumer]) + 1
+    spins := 0
+
+    for {
+        slot := uint64(next) & d.mask
+        if atomic.LoadInt64(&d.published[slot]) == next {
+            entry := d.entries[slot]
+            if entry.Consumer == consumer {
+                fn(entry)
+            }
+            atomic.StoreInt64(&d.consumerCursors[consumer], next)
+            next++
+            spins = 0
+            continue
+        }
+
+        if atomic.LoadInt32(&d.done) == 1 && next >= atomic.LoadInt64(&d.producerCursor) {
+            return // ring drained, producers are done
+        }
+
+        spins++
+        switch {
+        case spins < 100: // busy-spin
+        case spins < 1000:
+            runtime.Gosched()
+        default:
+            time.Sleep(time.Microsecond)
+        }
+    }
+}
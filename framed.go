@@ -0,0 +1,144 @@
+package main
+
+import (
+    "encoding/binary"
+    "fmt"
+    "sync"
+    "time"
+)
+
+const (
+    MaxFrameSize     = 1 << 20  // Largest payload PublishFramed will accept, in bytes
+    FramedChunkSize  = 64 << 10 // Arena growth step, in bytes
+)
+
+// EventBusDataFramed stores pointers to per-key framed cells, mirroring the
+// original EventBusData map-of-pointers pattern: every key is populated up
+// front so concurrent Producers/Consumers only ever read the map, never
+// write it.
+type EventBusDataFramed map[EventBusDataKey]*EventBusDataValueFramed
+
+// frameIndexEntry locates one message inside a cell's arena.
+type frameIndexEntry struct {
+    offset   int
+    length   int       // length of the varint prefix plus the payload
+    storedAt time.Time // when Append wrote this frame, used by the MaxAge retention policy
+}
+
+// EventBusDataValueFramed is an alternative to EventBusDataValueMessage that
+// lifts the MessageSizeMax fixed-array limit: a cell holds one contiguous
+// byte arena plus an index of (offset, length) entries, with a varint length
+// prefix written before each payload (msgio-style framing).
+//
+// Messages are addressed by absolute offset, counting from the first message
+// ever appended to the cell. base is the absolute offset of index[0]: it
+// advances when compact trims old entries, so offsets already handed out via
+// Subscribe/Seek/Tell keep meaning even after compaction. offsets tracks each
+// subscribed consumer's own read cursor, so a cell is broadcast to every
+// subscriber independently rather than partitioned across them.
+type EventBusDataValueFramed struct {
+    arena   []byte
+    index   []frameIndexEntry
+    base    int
+    offsets map[ConsumerID]*int64
+    mu      sync.Mutex // Guards arena, index, base and offsets
+}
+
+// NewEventBusDataValueFramed allocates an empty cell with room for one chunk.
+func NewEventBusDataValueFramed() *EventBusDataValueFramed {
+    return &EventBusDataValueFramed{
+        arena: make([]byte, 0, FramedChunkSize),
+    }
+}
+
+// Append writes payload into the arena behind a varint length prefix and
+// records its location in the index, growing the arena by FramedChunkSize
+// (or more, if payload alone doesn't fit) when it runs out of room.
+func (v *EventBusDataValueFramed) Append(payload []byte) error {
+    if len(payload) > MaxFrameSize {
+        return fmt.Errorf("framed: payload of %v bytes exceeds MaxFrameSize %v", len(payload), MaxFrameSize)
+    }
+
+    var lenBuf [binary.MaxVarintLen64]byte
+    n := binary.PutUvarint(lenBuf[:], uint64(len(payload)))
+    frame := n + len(payload)
+
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    v.growFor(frame)
+
+    offset := len(v.arena)
+    v.arena = append(v.arena, lenBuf[:n]...)
+    v.arena = append(v.arena, payload...)
+    v.index = append(v.index, frameIndexEntry{offset: offset, length: frame, storedAt: time.Now()})
+
+    return nil
+}
+
+// growFor ensures the arena has room for at least need more bytes.
+func (v *EventBusDataValueFramed) growFor(need int) {
+    if cap(v.arena)-len(v.arena) >= need {
+        return
+    }
+    grow := FramedChunkSize
+    for grow < need {
+        grow <<= 1
+    }
+    next := make([]byte, len(v.arena), cap(v.arena)+grow)
+    copy(next, v.arena)
+    v.arena = next
+}
+
+// Len reports how many messages are currently retained in the cell (oldest
+// ones may have been dropped by compact).
+func (v *EventBusDataValueFramed) Len() int {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    return len(v.index)
+}
+
+// Read returns the message at the given absolute offset. If alias is true,
+// the returned slice aliases the cell's arena directly (zero-copy, but only
+// valid until the next Append that triggers a grow, or the next compact);
+// otherwise the caller gets a private copy. Must hold v.mu.
+func (v *EventBusDataValueFramed) readLocked(offset int, alias bool) ([]byte, error) {
+    idx := offset - v.base
+    if idx < 0 || idx >= len(v.index) {
+        return nil, fmt.Errorf("framed: offset %v out of range [%v,%v)", offset, v.base, v.base+len(v.index))
+    }
+
+    e := v.index[idx]
+    frame := v.arena[e.offset : e.offset+e.length]
+
+    length, n := binary.Uvarint(frame)
+    payload := frame[n : n+int(length)]
+
+    if alias {
+        return payload, nil
+    }
+    cp := make([]byte, len(payload))
+    copy(cp, payload)
+    return cp, nil
+}
+
+// Read returns the message at the given absolute offset. See readLocked for
+// the alias semantics.
+func (v *EventBusDataValueFramed) Read(offset int, alias bool) ([]byte, error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+    return v.readLocked(offset, alias)
+}
+
+// PublishFramed writes payload to key's framed cell, bypassing the
+// MessageSizeMax fixed-array limit used by the ring buffer's
+// DisruptorEntry.Msg.
+func (b *EventBus) PublishFramed(key EventBusDataKey, payload []byte) error {
+    return b.framed[key].Append(payload)
+}
+
+// ReadFramed reads back the message at offset from key's framed cell. See
+// EventBusDataValueFramed.Read for the alias semantics.
+func (b *EventBus) ReadFramed(key EventBusDataKey, offset int, alias bool) ([]byte, error) {
+    return b.framed[key].Read(offset, alias)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+    "fmt"
+    "testing"
+)
+
+// BenchmarkPublishFixedVsFramed compares the fixed-array append path against
+// EventBusDataValueFramed.Append at increasing payload sizes. Fixed mode is
+// skipped above MessageSizeMax, since it can't hold larger payloads at all.
+func BenchmarkPublishFixedVsFramed(b *testing.B) {
+    for _, size := range []int{16, 256, 4096} {
+        payload := make([]byte, size)
+        for i := range payload {
+            payload[i] = byte(i)
+        }
+
+        b.Run(fmt.Sprintf("fixed/%vB", size), func(b *testing.B) {
+            if size > MessageSizeMax {
+                b.Skipf("fixed mode is capped at MessageSizeMax (%v bytes)", MessageSizeMax)
+            }
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                var mbyt EventBusDataValueMessage
+                copy(mbyt[:], payload)
+            }
+        })
+
+        b.Run(fmt.Sprintf("framed/%vB", size), func(b *testing.B) {
+            v := NewEventBusDataValueFramed()
+            b.ReportAllocs()
+            for i := 0; i < b.N; i++ {
+                if err := v.Append(payload); err != nil {
+                    b.Fatal(err)
+                }
+            }
+        })
+    }
+}
@@ -0,0 +1,91 @@
+//go:build kafka
+// +build kafka
+
+package main
+
+import (
+    "fmt"
+    "os"
+    "strings"
+
+    "github.com/Shopify/sarama"
+)
+
+// KafkaSinkConfig configures a KafkaSink. Zero-value RequiredAcks/Compression
+// fall back to sarama's own defaults (WaitForLocal / CompressionNone).
+type KafkaSinkConfig struct {
+    Brokers      []string
+    Topic        string
+    RequiredAcks sarama.RequiredAcks
+    Compression  sarama.CompressionCodec
+}
+
+// KafkaSink forwards messages to a Kafka topic via sarama's AsyncProducer. It
+// partitions by EventBusDataKey (hashed the same way producers bucket
+// messages into bus cells) so all messages for a given cell land on the same
+// partition and keep their publish order.
+type KafkaSink struct {
+    producer sarama.AsyncProducer
+    topic    string
+}
+
+// NewKafkaSink starts an AsyncProducer against cfg.Brokers and begins
+// draining its Errors channel in the background; Publish surfaces the most
+// recently observed error, if any, on the next call.
+func NewKafkaSink(cfg KafkaSinkConfig) (*KafkaSink, error) {
+    conf := sarama.NewConfig()
+    conf.Producer.Partitioner = sarama.NewHashPartitioner
+    conf.Producer.RequiredAcks = cfg.RequiredAcks
+    conf.Producer.Compression = cfg.Compression
+    conf.Producer.Return.Errors = true
+
+    producer, err := sarama.NewAsyncProducer(cfg.Brokers, conf)
+    if err != nil {
+        return nil, fmt.Errorf("kafka sink: %w", err)
+    }
+
+    return &KafkaSink{producer: producer, topic: cfg.Topic}, nil
+}
+
+func (s *KafkaSink) Publish(key EventBusDataKey, msg []byte) error {
+    select {
+    case err := <-s.producer.Errors():
+        return err.Err
+    default:
+    }
+
+    s.producer.Input() <- &sarama.ProducerMessage{
+        Topic: s.topic,
+        Key:   sarama.StringEncoder(fmt.Sprintf("%v", key)), // preserves per-cell ordering
+        Value: sarama.ByteEncoder(msg),
+    }
+    return nil
+}
+
+func (s *KafkaSink) Close() error {
+    return s.producer.Close()
+}
+
+// init registers the kafka backend under the name selected via SinkBackend
+// (see main.go). Config comes from the environment, since RegisterSink's
+// factory takes no arguments: KAFKA_BROKERS is a comma-separated list
+// (default "localhost:9092"), KAFKA_TOPIC is required.
+func init() {
+    RegisterSink("kafka", func() (Sink, error) {
+        topic := os.Getenv("KAFKA_TOPIC")
+        if topic == "" {
+            return nil, fmt.Errorf("kafka sink: KAFKA_TOPIC is required")
+        }
+
+        brokers := strings.Split(os.Getenv("KAFKA_BROKERS"), ",")
+        if len(brokers) == 1 && brokers[0] == "" {
+            brokers = []string{"localhost:9092"}
+        }
+
+        return NewKafkaSink(KafkaSinkConfig{
+            Brokers:      brokers,
+            Topic:        topic,
+            RequiredAcks: sarama.WaitForLocal,
+        })
+    })
+}
@@ -3,6 +3,7 @@ package main
 import (
     "fmt"
     "log"
+    "os"
     "time"
     "strconv"
     "math/rand"
@@ -15,9 +16,16 @@ const (
                                             // Bigger bus size - more concurrent operations can be done
 
     MessageSizeMax          = 16            // Event message max length, in bytes
-    PublishQueueLen         = 4<<10         // Max messages in a publish queue per Producer
+    DisruptorSize           = 1<<16         // Ring buffer size, rounded up to a power of two
     EventBusWriteDelayUs    = 1             // Emulate storage write acknowledged delay, in microsec
 
+    DefaultSinkBackend      = "stdout"      // Default registered Sink backend; override with SINK_BACKEND
+    SinkErrChanSize         = 1<<10         // Bounded error channel drained by the sink supervisor
+
+    ConsumerGroupBatchSize     = 1024            // Flush once a batch reaches this many messages
+    ConsumerGroupFlushInterval = 5 * time.Second // Flush a non-empty batch after this long
+    ConsumerGroupChanSize      = 1024            // Per-consumer merge channel buffer size
+
     // Exchange in parallel
     Producers               = 10            // Producers countevent_data_bus
                                             // More Producers and Consumers - more exchanges can be done
@@ -39,34 +47,15 @@ const (
 )
 
 type EventBus struct {
-    Data EventBusData
-    publishQueues EventBusPublishQueues // Published messages ready to be read
+    disruptor *Disruptor      // Shared ring buffer: all producers publish into it, all consumers read from it
+    framed    EventBusDataFramed // Per-key framed cells, for payloads over MessageSizeMax (see PublishFramed)
 }
 
-// Map stores pointers to the data value structs (bus cells). So we can access map values
-// on read/write from many threads without need to lock map itself.
-type EventBusData map[EventBusDataKey]*EventBusDataValue
-
 type EventBusDataKey uint16
 
-type EventBusDataValue struct {
-    Messages []EventBusDataValueMessage // Producer pushes messages to this list
-    offsetRead int64 // Consumer reads messages from this offset
-    mu sync.RWMutex // Producers can publish messages with equal bus keys at the same time
-}
-
 // Used fixed-size bytes array to guarantee, that value will not be escaped to the heap
 type EventBusDataValueMessage [MessageSizeMax]byte
 
-// For simplicity, we use notification channels per pair Producer-Consumer. Each pair has
-// it's own notification channel.
-type EventBusPublishQueues map[int]*chan EventBusPublishQueuesChanValue
-
-type EventBusPublishQueuesChanValue struct {
-    Key EventBusDataKey
-    MsgNum int // Message num at the Producer
-}
-
 // Simple func to calculate bus key (~hash) for the message. Equal messages must have equal keys.
 func (b *EventBus) Key(msg string) EventBusDataKey {
     s := 0
@@ -84,19 +73,23 @@ func main() {
 
     // Init event data bus
 
-    fmt.Printf("Initializing event data bus: size: %v\n", EventBusSize)
-    bus := &EventBus{Data: make(EventBusData, EventBusSize)}
+    fmt.Printf("Initializing event data bus: ring size: %v\n", DisruptorSize)
+    bus := &EventBus{disruptor: NewDisruptor(DisruptorSize, Consumers)}
+
+    bus.framed = make(EventBusDataFramed, EventBusSize)
     for bk := EventBusDataKey(0); bk < EventBusDataKey(EventBusSize); bk++ {
-        bus.Data[bk] = &EventBusDataValue{
-            Messages: make([]EventBusDataValueMessage, 0, ProducerMessages),
-        }
+        bus.framed[bk] = NewEventBusDataValueFramed()
     }
 
-    // Initializing notification channels per pair Producer-Consumer
-    bus.publishQueues = make(EventBusPublishQueues, Producers)
-    for p := 0; p < Producers; p++ {
-        ch := make(chan EventBusPublishQueuesChanValue, PublishQueueLen)
-        bus.publishQueues[p] = &ch
+    sinkBackend := DefaultSinkBackend
+    if v := os.Getenv("SINK_BACKEND"); v != "" { // e.g. SINK_BACKEND=kafka (build with -tags kafka)
+        sinkBackend = v
+    }
+
+    fmt.Printf("Initializing sink backend: %v\n", sinkBackend)
+    sink, err := NewSink(sinkBackend)
+    if err != nil {
+        log.Fatalln(err)
     }
 
     // Generate messages
@@ -141,8 +134,26 @@ func main() {
 
     timeStart := time.Now()
 
+    // Sink supervisor: drains sink errors off the hot path, logs them and
+    // keeps a failure count for the final report
+
+    sinkErrs := make(chan error, SinkErrChanSize)
+    var sinkFailures int64
+
+    var wgSupervisor sync.WaitGroup
+    wgSupervisor.Add(1)
+    go func() {
+        defer wgSupervisor.Done()
+        for err := range sinkErrs {
+            atomic.AddInt64(&sinkFailures, 1)
+            log.Printf("sink[%v]: publish failed: %v", sinkBackend, err)
+        }
+    }()
+
     // Producers
 
+    var producersDone int32 // Counts producers that finished, to close the disruptor exactly once
+
     for p := 0; p < Producers; p++ {
         p := p // for compatibility with older versions of golang
 
@@ -150,34 +161,50 @@ func main() {
             for m := 0; m < ProducerMessages; m++ { // Publish messages (write to the Bus)
 
                 msg := messages[p][m] // Message to publish (write)
-                bk := messagesKeys[p][m] // Bus key
-                bv := bus.Data[bk] // Pointer to bus value (cell). We access map only on read.
+                bk := messagesKeys[p][m] // Bus key, kept as an optional routing layer
 
                 var mbyt EventBusDataValueMessage
                 copy(mbyt[:], msg) // Convert string to array of bytes
 
-                func() { // Use local func and defer unlock to guarantee that unlock will be done
-                    bv.mu.Lock()
-                    defer bv.mu.Unlock()
-
-                    bv.Messages = append(bv.Messages, mbyt) // Write message to the bus
-
-                    if EventBusWriteDelayUs > 0 { // Emulate write delay
-                        time.Sleep(time.Microsecond * EventBusWriteDelayUs)
-                    }
-                    if Verbose {
-                        fmt.Printf("producer[%v]: msgNum: %v, busKey: %v, msg: %v\n", p, m, bk, msg)
-                    }
-                }()
+                if EventBusWriteDelayUs > 0 { // Emulate write delay
+                    time.Sleep(time.Microsecond * EventBusWriteDelayUs)
+                }
+                if Verbose {
+                    fmt.Printf("producer[%v]: msgNum: %v, busKey: %v, msg: %v\n", p, m, bk, msg)
+                }
 
-                // Notify Consumer that bus was written
-                *bus.publishQueues[p] <- EventBusPublishQueuesChanValue{Key: bk, MsgNum: m}
+                // Claim a slot and publish it; Consumer p reads back what Producer p wrote
+                bus.disruptor.Publish(DisruptorEntry{Key: bk, Consumer: p, Msg: mbyt})
+            }
+            if atomic.AddInt32(&producersDone, 1) == Producers {
+                bus.disruptor.Close() // Last producer signals consumers that the ring will drain
             }
-            close(*bus.publishQueues[p])
         }()
     }
 
     // Consumers
+    // Each consumer batches its messages through a ConsumerGroup instead of
+    // publishing to the sink one message at a time.
+
+    groups := make([]*ConsumerGroup, Consumers)
+    for c := range groups {
+        groups[c] = NewConsumerGroup(ConsumerGroupConfig{
+            BatchSize:     ConsumerGroupBatchSize,
+            FlushInterval: ConsumerGroupFlushInterval,
+            ChanSize:      ConsumerGroupChanSize,
+        })
+        groups[c].Run(func(msgs [][]byte) {
+            for _, msg := range msgs {
+                key := bus.Key(string(msg)) // Recompute the bus key, lost when a message enters the batch
+                if err := sink.Publish(key, msg); err != nil {
+                    select {
+                    case sinkErrs <- err:
+                    default: // supervisor can't keep up, don't block the hot path
+                    }
+                }
+            }
+        })
+    }
 
     var wgConsumers sync.WaitGroup
     wgConsumers.Add(Consumers)
@@ -188,31 +215,25 @@ func main() {
         go func() { // Run consumer
             defer wgConsumers.Done()
 
-            for pub := range *bus.publishQueues[c] { // Read as soon as bus write event occurred
-
-                bk := pub.Key // Bus key
-                bv := bus.Data[bk] // Pointer to bus value (cellevent_data_bus). We access map only on read.
-
-                func() { // Use local func and defer unlock to guarantee that unlock will be done
-                    bv.mu.RLock()
-                    defer bv.mu.RUnlock()
-
-                    // Use atomic, as we have only read-lock here
-                    offset := atomic.LoadInt64(&bv.offsetRead)
-                    atomic.AddInt64(&bv.offsetRead, 1) // offsetRead++
-                    //runtime.Gosched() // Not need
-
-                    msg := string(bv.Messages[offset][:]) // Read message from the bus
-
-                    if Verbose {
-                        fmt.Printf("consumer[%v]: msgNum: %v, busKey: %v, msg: %v\n", c, pub.MsgNum, bk, msg)
-                    }
-                }()
-            }
+            msgNum := 0
+            bus.disruptor.Consume(c, func(entry DisruptorEntry) { // Read as soon as the slot is published
+                if Verbose {
+                    fmt.Printf("consumer[%v]: msgNum: %v, busKey: %v, msg: %v\n", c, msgNum, entry.Key, string(entry.Msg[:]))
+                }
+                msgNum++
+                groups[c].Feed(entry.Msg[:]) // Hand off to the batcher instead of publishing here
+            })
+            groups[c].Close() // Drain and flush whatever the batcher is still holding
         }()
     }
     wgConsumers.Wait() // Wait till Consumers have read all messages
 
+    close(sinkErrs)
+    wgSupervisor.Wait()
+    if err := sink.Close(); err != nil {
+        log.Printf("sink[%v]: close failed: %v", sinkBackend, err)
+    }
+
     // Result
     timeTaken := time.Since(timeStart).Milliseconds()
     rps := 0
@@ -221,4 +242,80 @@ func main() {
     }
     fmt.Printf("RPS: %v\n", rps)
     fmt.Printf("Time taken: %v ms\n", timeTaken)
+    fmt.Printf("Sink failures: %v\n", atomic.LoadInt64(&sinkFailures))
+
+    demoFramedStorage(bus)
+    demoFramedReplay(bus)
+}
+
+// demoFramedKey is the cell demoFramedStorage populates; it runs after the
+// timed exchange above so it doesn't skew RPS.
+const demoFramedKey = EventBusDataKey(0)
+
+// demoFramedStorage exercises the framed store directly: publish a handful
+// of variable-length messages to demoFramedKey via PublishFramed, then read
+// them back by absolute offset via ReadFramed.
+func demoFramedStorage(bus *EventBus) {
+    for i := 0; i < 5; i++ {
+        if err := bus.PublishFramed(demoFramedKey, []byte(fmt.Sprintf("framed-demo-%v", i))); err != nil {
+            log.Printf("framed demo: publish failed: %v", err)
+            return
+        }
+    }
+
+    for i := 0; i < 5; i++ {
+        msg, err := bus.ReadFramed(demoFramedKey, i, false)
+        if err != nil {
+            log.Printf("framed demo: read failed: %v", err)
+            return
+        }
+        if Verbose {
+            fmt.Printf("framed storage[%v]: %v\n", i, string(msg))
+        }
+    }
+}
+
+// demoFramedReplay exercises the framed store's broadcast/replay API on top
+// of the messages demoFramedStorage already published: two consumers
+// subscribe to the same key, read forward independently, one seeks back into
+// history, and a retention compactor trims what both have already passed.
+func demoFramedReplay(bus *EventBus) {
+    const replayerA, replayerB = ConsumerID(1001), ConsumerID(1002)
+
+    bus.Subscribe(replayerA, demoFramedKey)
+    bus.Subscribe(replayerB, demoFramedKey)
+
+    for i := 0; i < 5; i++ {
+        msg, err := bus.ReadNext(replayerA, demoFramedKey, false)
+        if err != nil {
+            log.Printf("framed demo: read failed: %v", err)
+            return
+        }
+        if Verbose {
+            fmt.Printf("framed replay[%v]: %v\n", replayerA, string(msg))
+        }
+    }
+
+    if err := bus.Seek(replayerA, demoFramedKey, 2); err != nil {
+        log.Printf("framed demo: seek failed: %v", err)
+        return
+    }
+    if replayed, err := bus.ReadNext(replayerA, demoFramedKey, false); err == nil && Verbose {
+        fmt.Printf("framed replay[%v] after seek: %v\n", replayerA, string(replayed))
+    }
+
+    // replayerB hasn't advanced yet, so nothing is eligible to compact away
+    stop := bus.StartCompactor(RetentionPolicy{MaxMessages: 2}, 10*time.Millisecond)
+    time.Sleep(30 * time.Millisecond)
+    fmt.Printf("framed demo: retained before replayerB catches up: %v\n", bus.framed[demoFramedKey].Len())
+
+    for i := 0; i < 5; i++ {
+        if _, err := bus.ReadNext(replayerB, demoFramedKey, false); err != nil {
+            log.Printf("framed demo: read failed: %v", err)
+            break
+        }
+    }
+    time.Sleep(30 * time.Millisecond)
+    fmt.Printf("framed demo: retained after replayerB catches up: %v\n", bus.framed[demoFramedKey].Len())
+    stop()
 }
@@ -0,0 +1,196 @@
+package main
+
+import (
+    "fmt"
+    "sync/atomic"
+    "time"
+)
+
+// ConsumerID identifies an independent reader of a framed cell. Unlike the
+// disruptor's Consumer index, which statically pairs a consumer to the
+// producer it reads from, a ConsumerID can Subscribe to any set of keys and
+// gets its own cursor per key, enabling Kafka-style broadcast and replay.
+type ConsumerID int
+
+// RetentionPolicy bounds how much history a framed cell keeps. Zero value
+// disables that half of the policy (unlimited).
+type RetentionPolicy struct {
+    MaxMessages int           // Keep at most this many messages per cell
+    MaxAge      time.Duration // Drop messages older than this
+}
+
+// subscribe registers consumer as a reader of v, starting at the oldest
+// retained message. Re-subscribing an already-known consumer is a no-op, so
+// it doesn't rewind an in-progress cursor.
+func (v *EventBusDataValueFramed) subscribe(consumer ConsumerID) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if v.offsets == nil {
+        v.offsets = make(map[ConsumerID]*int64)
+    }
+    if _, ok := v.offsets[consumer]; !ok {
+        start := int64(v.base)
+        v.offsets[consumer] = &start
+    }
+}
+
+// seek moves consumer's cursor to offset (an absolute message number).
+func (v *EventBusDataValueFramed) seek(consumer ConsumerID, offset int64) error {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    cur, ok := v.offsets[consumer]
+    if !ok {
+        return fmt.Errorf("framed: consumer %v is not subscribed", consumer)
+    }
+    if offset < int64(v.base) || offset > int64(v.base+len(v.index)) {
+        return fmt.Errorf("framed: offset %v out of range [%v,%v]", offset, v.base, v.base+len(v.index))
+    }
+    atomic.StoreInt64(cur, offset)
+    return nil
+}
+
+// tell reports consumer's current cursor.
+func (v *EventBusDataValueFramed) tell(consumer ConsumerID) (int64, error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    cur, ok := v.offsets[consumer]
+    if !ok {
+        return 0, fmt.Errorf("framed: consumer %v is not subscribed", consumer)
+    }
+    return atomic.LoadInt64(cur), nil
+}
+
+// readNext reads the message at consumer's cursor and advances it past it.
+// See readLocked for the alias semantics.
+func (v *EventBusDataValueFramed) readNext(consumer ConsumerID, alias bool) ([]byte, error) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    cur, ok := v.offsets[consumer]
+    if !ok {
+        return nil, fmt.Errorf("framed: consumer %v is not subscribed", consumer)
+    }
+
+    msg, err := v.readLocked(int(atomic.LoadInt64(cur)), alias)
+    if err != nil {
+        return nil, err
+    }
+    atomic.AddInt64(cur, 1)
+    return msg, nil
+}
+
+// compact trims frames from the front of the cell once every subscribed
+// consumer's cursor has moved past them, subject to policy. A frame is
+// eligible once it's both past every subscribed cursor and in violation of
+// MaxMessages or MaxAge; eligible frames are always dropped oldest-first.
+func (v *EventBusDataValueFramed) compact(policy RetentionPolicy) {
+    v.mu.Lock()
+    defer v.mu.Unlock()
+
+    if len(v.index) == 0 {
+        return
+    }
+
+    safe := len(v.index) // how many leading entries every subscribed consumer has passed
+    for _, cur := range v.offsets {
+        if passed := int(atomic.LoadInt64(cur)) - v.base; passed < safe {
+            safe = passed
+        }
+    }
+    if safe <= 0 {
+        return
+    }
+
+    dropByCount := 0
+    if policy.MaxMessages > 0 && len(v.index) > policy.MaxMessages {
+        dropByCount = len(v.index) - policy.MaxMessages
+    }
+
+    dropByAge := 0
+    if policy.MaxAge > 0 {
+        cutoff := time.Now().Add(-policy.MaxAge)
+        for dropByAge < len(v.index) && v.index[dropByAge].storedAt.Before(cutoff) {
+            dropByAge++
+        }
+    }
+
+    drop := dropByCount
+    if dropByAge > drop {
+        drop = dropByAge
+    }
+    if drop > safe {
+        drop = safe
+    }
+    if drop == 0 {
+        return
+    }
+
+    freed := v.index[drop-1].offset + v.index[drop-1].length
+    arena := make([]byte, len(v.arena)-freed, cap(v.arena))
+    copy(arena, v.arena[freed:])
+    v.arena = arena
+
+    index := make([]frameIndexEntry, len(v.index)-drop)
+    for i, e := range v.index[drop:] {
+        index[i] = frameIndexEntry{offset: e.offset - freed, length: e.length, storedAt: e.storedAt}
+    }
+    v.index = index
+    v.base += drop
+}
+
+// Subscribe registers consumer as an independent reader of each of keys. Use
+// Seek/Tell/ReadNext afterwards to replay or follow each key's history.
+func (b *EventBus) Subscribe(consumer ConsumerID, keys ...EventBusDataKey) {
+    for _, key := range keys {
+        b.framed[key].subscribe(consumer)
+    }
+}
+
+// Seek moves consumer's cursor for key to offset (an absolute message
+// number), enabling replay from arbitrary history.
+func (b *EventBus) Seek(consumer ConsumerID, key EventBusDataKey, offset int64) error {
+    return b.framed[key].seek(consumer, offset)
+}
+
+// Tell reports consumer's current cursor for key.
+func (b *EventBus) Tell(consumer ConsumerID, key EventBusDataKey) (int64, error) {
+    return b.framed[key].tell(consumer)
+}
+
+// ReadNext reads the next message for consumer from key, starting at its
+// subscribed cursor, and advances the cursor past it. If alias is true, the
+// returned slice aliases the cell's arena directly (zero-copy, but only
+// valid until the next Append that triggers a grow, or the next compact);
+// otherwise the caller gets a private copy.
+func (b *EventBus) ReadNext(consumer ConsumerID, key EventBusDataKey, alias bool) ([]byte, error) {
+    return b.framed[key].readNext(consumer, alias)
+}
+
+// StartCompactor launches a goroutine that applies policy to every framed
+// cell every interval, trimming messages once all of a cell's subscribed
+// consumers have advanced past the cutoff. Call the returned stop func to
+// end it.
+func (b *EventBus) StartCompactor(policy RetentionPolicy, interval time.Duration) (stop func()) {
+    done := make(chan struct{})
+
+    go func() {
+        ticker := time.NewTicker(interval)
+        defer ticker.Stop()
+
+        for {
+            select {
+            case <-ticker.C:
+                for _, cell := range b.framed {
+                    cell.compact(policy)
+                }
+            case <-done:
+                return
+            }
+        }
+    }()
+
+    return func() { close(done) }
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+// TestFramedOffsetsSeekTellCompact covers Subscribe/Seek/Tell/ReadNext and
+// the compactor, through the EventBus methods rather than poking the cell
+// directly.
+func TestFramedOffsetsSeekTellCompact(t *testing.T) {
+    bus := &EventBus{framed: EventBusDataFramed{0: NewEventBusDataValueFramed()}}
+    const key = EventBusDataKey(0)
+    const a, b = ConsumerID(1), ConsumerID(2)
+
+    for i := 0; i < 5; i++ {
+        if err := bus.PublishFramed(key, []byte{byte(i)}); err != nil {
+            t.Fatalf("PublishFramed(%v): %v", i, err)
+        }
+    }
+
+    bus.Subscribe(a, key)
+    bus.Subscribe(b, key)
+
+    for i := 0; i < 5; i++ {
+        msg, err := bus.ReadNext(a, key, false)
+        if err != nil {
+            t.Fatalf("ReadNext(a) #%v: %v", i, err)
+        }
+        if len(msg) != 1 || msg[0] != byte(i) {
+            t.Fatalf("ReadNext(a) #%v = %v, want [%v]", i, msg, i)
+        }
+    }
+
+    if tell, err := bus.Tell(a, key); err != nil || tell != 5 {
+        t.Fatalf("Tell(a) = %v, %v, want 5, nil", tell, err)
+    }
+
+    if err := bus.Seek(a, key, 2); err != nil {
+        t.Fatalf("Seek(a, 2): %v", err)
+    }
+    if msg, err := bus.ReadNext(a, key, false); err != nil || len(msg) != 1 || msg[0] != 2 {
+        t.Fatalf("ReadNext(a) after seek = %v, %v, want [2], nil", msg, err)
+    }
+
+    if err := bus.Seek(a, key, 99); err == nil {
+        t.Fatal("Seek(a, 99) should fail: offset out of range")
+    }
+
+    // b hasn't read anything yet, so nothing is eligible to compact away.
+    bus.framed[key].compact(RetentionPolicy{MaxMessages: 2})
+    if n := bus.framed[key].Len(); n != 5 {
+        t.Fatalf("Len() after compact with b not caught up = %v, want 5", n)
+    }
+
+    for i := 0; i < 5; i++ {
+        if _, err := bus.ReadNext(b, key, false); err != nil {
+            t.Fatalf("ReadNext(b) #%v: %v", i, err)
+        }
+    }
+    bus.framed[key].compact(RetentionPolicy{MaxMessages: 2})
+    if n := bus.framed[key].Len(); n != 2 {
+        t.Fatalf("Len() after compact with both caught up = %v, want 2", n)
+    }
+}
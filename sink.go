@@ -0,0 +1,49 @@
+package main
+
+import "fmt"
+
+// Sink is a pluggable delivery target a consumer goroutine forwards read
+// messages to, instead of just logging them.
+type Sink interface {
+    Publish(key EventBusDataKey, msg []byte) error
+    Close() error
+}
+
+// SinkFactory builds a configured Sink. Backends register a factory under a
+// name so the one to use can be picked at runtime (e.g. via a config knob).
+type SinkFactory func() (Sink, error)
+
+var sinkRegistry = map[string]SinkFactory{
+    "stdout": func() (Sink, error) { return &StdoutSink{}, nil },
+}
+
+// RegisterSink adds (or overrides) a named sink backend in the registry.
+func RegisterSink(name string, factory SinkFactory) {
+    sinkRegistry[name] = factory
+}
+
+// NewSink builds the named sink backend.
+func NewSink(name string) (Sink, error) {
+    factory, ok := sinkRegistry[name]
+    if !ok {
+        return nil, fmt.Errorf("sink: unknown backend %q", name)
+    }
+    return factory()
+}
+
+// StdoutSink writes every message to stdout when Verbose, and otherwise just
+// counts them. It's the default backend and needs no external system, useful
+// as a sample and a fallback; gated on Verbose so it stays a quiet throughput
+// demo by default, like every other per-message trace in main.go.
+type StdoutSink struct{}
+
+func (s *StdoutSink) Publish(key EventBusDataKey, msg []byte) error {
+    if Verbose {
+        fmt.Printf("sink[stdout]: busKey: %v, msg: %v\n", key, string(msg))
+    }
+    return nil
+}
+
+func (s *StdoutSink) Close() error {
+    return nil
+}
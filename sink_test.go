@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestNewSinkStdout(t *testing.T) {
+    sink, err := NewSink("stdout")
+    if err != nil {
+        t.Fatalf("NewSink(stdout): %v", err)
+    }
+    if _, ok := sink.(*StdoutSink); !ok {
+        t.Fatalf("NewSink(stdout) = %T, want *StdoutSink", sink)
+    }
+}
+
+func TestNewSinkUnknownBackend(t *testing.T) {
+    if _, err := NewSink("bogus"); err == nil {
+        t.Fatal("NewSink(bogus) should fail: backend isn't registered")
+    }
+}
+
+func TestRegisterSinkOverridesBackend(t *testing.T) {
+    defer func() { sinkRegistry["stdout"] = func() (Sink, error) { return &StdoutSink{}, nil } }()
+
+    built := false
+    RegisterSink("stdout", func() (Sink, error) {
+        built = true
+        return &StdoutSink{}, nil
+    })
+
+    if _, err := NewSink("stdout"); err != nil {
+        t.Fatalf("NewSink(stdout) after override: %v", err)
+    }
+    if !built {
+        t.Fatal("NewSink(stdout) didn't use the overriding factory")
+    }
+
+    RegisterSink("new-backend", func() (Sink, error) { return &StdoutSink{}, nil })
+    defer delete(sinkRegistry, "new-backend")
+
+    if _, err := NewSink("new-backend"); err != nil {
+        t.Fatalf("NewSink(new-backend): %v", err)
+    }
+}